@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// emitOutputs sets GitHub Actions step outputs. `set-output` is deprecated,
+// so by default outputs are appended to the file at $GITHUB_OUTPUT; pass
+// legacy=true to fall back to the old "::set-output" workflow command for
+// runners that haven't migrated yet.
+func emitOutputs(outputs map[string]string, legacy bool) error {
+	if legacy {
+		for key, value := range outputs {
+			fmt.Printf("::set-output name=%s::%s\n", key, value)
+		}
+		return nil
+	}
+
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		// Not running inside GitHub Actions (or GITHUB_OUTPUT isn't set);
+		// nothing to write to.
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening GITHUB_OUTPUT file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	for key, value := range outputs {
+		if _, err := fmt.Fprintf(f, "%s=%s\n", key, value); err != nil {
+			return fmt.Errorf("writing to GITHUB_OUTPUT file %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmitOutputsWritesGithubOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "github_output")
+	t.Setenv("GITHUB_OUTPUT", path)
+
+	outputs := map[string]string{"approval_security": "true"}
+	if err := emitOutputs(outputs, false); err != nil {
+		t.Fatalf("emitOutputs: unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading GITHUB_OUTPUT file: %v", err)
+	}
+	if got, want := string(data), "approval_security=true\n"; got != want {
+		t.Fatalf("GITHUB_OUTPUT contents = %q, want %q", got, want)
+	}
+}
+
+func TestEmitOutputsAppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "github_output")
+	if err := os.WriteFile(path, []byte("existing=value\n"), 0o644); err != nil {
+		t.Fatalf("seeding GITHUB_OUTPUT file: %v", err)
+	}
+	t.Setenv("GITHUB_OUTPUT", path)
+
+	if err := emitOutputs(map[string]string{"approval_security": "true"}, false); err != nil {
+		t.Fatalf("emitOutputs: unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading GITHUB_OUTPUT file: %v", err)
+	}
+	if !strings.Contains(string(data), "existing=value\n") || !strings.Contains(string(data), "approval_security=true\n") {
+		t.Fatalf("GITHUB_OUTPUT contents = %q, want both the existing and new lines", string(data))
+	}
+}
+
+func TestEmitOutputsWithoutGithubOutputIsANoop(t *testing.T) {
+	t.Setenv("GITHUB_OUTPUT", "")
+
+	if err := emitOutputs(map[string]string{"approval_security": "true"}, false); err != nil {
+		t.Fatalf("emitOutputs: unexpected error: %v", err)
+	}
+}
+
+func TestEmitOutputsLegacySetOutput(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("creating pipe: %v", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	err = emitOutputs(map[string]string{"approval_security": "true"}, true)
+	w.Close()
+	if err != nil {
+		t.Fatalf("emitOutputs: unexpected error: %v", err)
+	}
+
+	var buf strings.Builder
+	if _, err := io.Copy(&buf, r); err != nil {
+		t.Fatalf("reading captured stdout: %v", err)
+	}
+
+	want := "::set-output name=approval_security::true\n"
+	if buf.String() != want {
+		t.Fatalf("captured stdout = %q, want %q", buf.String(), want)
+	}
+}
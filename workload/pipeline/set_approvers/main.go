@@ -5,175 +5,356 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	tfjson "github.com/hashicorp/terraform-json"
 )
 
-// Define the top-level TerraformPlan struct
-type TerraformPlan struct {
-	FormatVersion    string              `json:"format_version"`
-	TerraformVersion string              `json:"terraform_version"`
-	Variables        map[string]Variable `json:"variables"`
-	PlannedValues    PlannedValues       `json:"planned_values"`
-	ResourceChanges  []ResourceChange    `json:"resource_changes"`
-	Configuration    Configuration       `json:"configuration"`
-	Timestamp        string              `json:"timestamp"`
-	Errored          bool                `json:"errored"`
+// resourceContext is the subset of a resource change that rules match
+// against. Drifted is true when the change came from plan.ResourceDrift
+// (a divergence between prior state and real infrastructure) rather than
+// plan.ResourceChanges (the diff Terraform intends to apply).
+type resourceContext struct {
+	Address  string
+	Type     string
+	Mode     tfjson.ResourceMode
+	Provider string
+	Change   *tfjson.Change
+	Drifted  bool
 }
 
-// Variables
+// sensitiveRuleName identifies the implicit sensitive-attribute check in
+// rule matches and reports, alongside the rules loaded from policy.
+// sensitiveSeverity puts it on par with the built-in Security rules when
+// resolving primary_approval_group.
+const (
+	sensitiveRuleName = "sensitive-attribute-change"
+	sensitiveSeverity = 100
+)
 
-type Variable struct {
-	Value       interface{} `json:"value"`
-	Description string      `json:"description,omitempty"`
-	Sensitive   bool        `json:"sensitive,omitempty"`
+// ruleMatch records one (rule, resource) pair where a policy rule (or the
+// implicit sensitive-attribute check) required approval, feeding both the
+// console log and the JUnit/JSON reports.
+type ruleMatch struct {
+	Rule     string
+	Group    string
+	Severity int
+	Action   string
+	Address  string
+	Summary  string
 }
 
-// Planned Values
+func main() {
+	tfPlanPath := flag.String("tfplan", "", "Path to the Terraform plan file in JSON format")
+	policyPath := flag.String("policy", "", "Path to a YAML policy file describing approval rules (defaults to the built-in Jamf Pro security rules)")
+	reportJUnitPath := flag.String("report-junit", "", "Write a JUnit XML report (one testcase per policy rule) to this path")
+	reportJSONPath := flag.String("report-json", "", "Write a JSON report of the matched rules and approval groups to this path")
+	githubActions := flag.Bool("github-actions", false, "Emit legacy '::set-output' workflow commands instead of writing to $GITHUB_OUTPUT")
+	flag.Parse()
 
-type PlannedValues struct {
-	RootModule RootModule `json:"root_module"`
-}
+	if *tfPlanPath == "" {
+		fmt.Println("Usage: -tfplan <path to terraform plan json> [-policy <path to policy yaml>]")
+		return
+	}
 
-// Define a struct for the RootModule part
-type RootModule struct {
-	Resources []Resource `json:"resources"`
-}
+	planFile, err := os.ReadFile(*tfPlanPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading plan file: %v\n", err)
+		os.Exit(1)
+	}
 
-// Define a struct for each Resource
-type Resource struct {
-	Address string         `json:"address"`
-	Type    string         `json:"type"`
-	Values  ResourceValues `json:"values"`
-}
+	var plan tfjson.Plan
+	if err := json.Unmarshal(planFile, &plan); err != nil {
+		fmt.Fprintf(os.Stderr, "Error unmarshalling JSON: %v\n", err)
+		os.Exit(1)
+	}
 
-// Define a struct for the Values part
-type ResourceValues struct {
-	Name string `json:"name"`
-}
+	if err := checkFormatVersion(plan.FormatVersion); err != nil {
+		fmt.Fprintf(os.Stderr, "Unsupported plan format: %v\n", err)
+		os.Exit(1)
+	}
 
-// Resource Change
+	contexts := buildResourceContexts(&plan)
 
-type ResourceChange struct {
-	Address  string `json:"address"`
-	Mode     string `json:"mode"`
-	Type     string `json:"type"`
-	Name     string `json:"name"`
-	Provider string `json:"provider_name"`
-	Change   Change `json:"change"`
-}
+	policy := defaultPolicy()
+	if *policyPath != "" {
+		policy, err = LoadPolicy(*policyPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading policy file: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-type Change struct {
-	Actions         []string               `json:"actions"`
-	Before          map[string]interface{} `json:"before"`
-	After           map[string]interface{} `json:"after"`
-	AfterUnknown    map[string]interface{} `json:"after_unknown"`
-	BeforeSensitive bool                   `json:"before_sensitive"`
-	AfterSensitive  map[string]interface{} `json:"after_sensitive"`
-}
+	matches, err := evaluatePolicy(policy, contexts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error evaluating policy: %v\n", err)
+		os.Exit(1)
+	}
+	matches = append(matches, evaluateOutputChanges(plan.OutputChanges)...)
+
+	groups := approvalGroups(matches)
+	denied := denyMatches(matches)
+
+	if *reportJUnitPath != "" {
+		if err := writeJUnitReport(*reportJUnitPath, ruleNames(policy), matches); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JUnit report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *reportJSONPath != "" {
+		if err := writeJSONReport(*reportJSONPath, &plan, matches, groups); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if len(groups) == 0 && len(denied) == 0 {
+		fmt.Println("No security-related changes detected.")
+		return
+	}
+
+	outputs := make(map[string]string, len(groups)+2)
+	if len(groups) > 0 {
+		outputs["approval_groups"] = strings.Join(groups, ",")
+		outputs["primary_approval_group"] = primaryApprovalGroup(matches)
+		for _, group := range groups {
+			fmt.Printf("Approval required from group: %s\n", group)
+			outputs[outputKey(group)] = "true"
+		}
+	}
 
-// Configuration
+	if err := emitOutputs(outputs, *githubActions); err != nil {
+		fmt.Fprintf(os.Stderr, "Error emitting GitHub Actions outputs: %v\n", err)
+		os.Exit(1)
+	}
 
-type Configuration struct {
-	ProviderConfig map[string]ProviderConfig `json:"provider_config"`
-	RootModule     RootModuleConfig          `json:"root_module"`
+	if len(denied) > 0 {
+		for _, m := range denied {
+			fmt.Fprintf(os.Stderr, "Denied: rule %q matched %s (%s) — this change must never be planned\n", m.Rule, m.Address, m.Summary)
+		}
+		os.Exit(1)
+	}
 }
 
-type ProviderConfig struct {
-	Name              string              `json:"name"`
-	FullName          string              `json:"full_name"`
-	VersionConstraint string              `json:"version_constraint"`
-	Expressions       ProviderExpressions `json:"expressions"`
+// evaluatePolicy matches every resource change against the implicit
+// sensitive-attribute check and every policy rule, returning every match
+// found, in evaluation order. This covers both plan.ResourceChanges and
+// plan.ResourceDrift, since a disk encryption setting disabled outside
+// Terraform is exactly the kind of security-relevant change this tool
+// exists to catch.
+func evaluatePolicy(policy *Policy, contexts []*resourceContext) ([]ruleMatch, error) {
+	var matches []ruleMatch
+
+	for _, ctx := range contexts {
+		if sensitiveValueChanged(ctx.Change) {
+			fmt.Printf("Sensitive attribute %s detected on %s: routing to %s\n", changeOrDrift(ctx.Drifted), ctx.Address, sensitiveApprovalGroup)
+			matches = append(matches, ruleMatch{
+				Rule:     sensitiveRuleName,
+				Group:    sensitiveApprovalGroup,
+				Severity: sensitiveSeverity,
+				Action:   approveAction,
+				Address:  ctx.Address,
+				Summary:  fmt.Sprintf("sensitive attribute value %sd", changeOrDrift(ctx.Drifted)),
+			})
+		}
+
+		for _, rule := range policy.Rules {
+			matched, err := rule.Matches(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				continue
+			}
+
+			fmt.Printf("Policy rule %q matched %s: routing to %s (%s)\n", rule.Name, ctx.Address, rule.Group, rule.action())
+			matches = append(matches, ruleMatch{
+				Rule:     rule.Name,
+				Group:    rule.Group,
+				Severity: rule.Severity,
+				Action:   rule.action(),
+				Address:  ctx.Address,
+				Summary:  fmt.Sprintf("%s on %s", actionSummary(ctx.Change), ctx.Type),
+			})
+		}
+	}
+
+	return matches, nil
 }
 
-type ProviderExpressions struct {
-	ClientID     Expression `json:"client_id"`
-	ClientSecret Expression `json:"client_secret"`
-	InstanceName Expression `json:"instance_name"`
-	LogLevel     Expression `json:"log_level"`
+// changeOrDrift renders whether a resourceContext came from a planned change
+// or from out-of-band drift, for log lines and match summaries.
+func changeOrDrift(drifted bool) string {
+	if drifted {
+		return "drift"
+	}
+	return "change"
 }
 
-type Expression struct {
-	ConstantValue string   `json:"constant_value,omitempty"`
-	References    []string `json:"references,omitempty"`
+// evaluateOutputChanges checks every output in the plan for a sensitive
+// value change, the same way evaluatePolicy checks resource changes.
+// Outputs have no resource type/mode/provider for policy rules to match
+// against, so only the implicit sensitive-attribute check applies to them.
+func evaluateOutputChanges(outputChanges map[string]*tfjson.Change) []ruleMatch {
+	names := make([]string, 0, len(outputChanges))
+	for name := range outputChanges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var matches []ruleMatch
+	for _, name := range names {
+		change := outputChanges[name]
+		if !sensitiveValueChanged(change) {
+			continue
+		}
+
+		address := "output." + name
+		fmt.Printf("Sensitive output change detected on %s: routing to %s\n", address, sensitiveApprovalGroup)
+		matches = append(matches, ruleMatch{
+			Rule:     sensitiveRuleName,
+			Group:    sensitiveApprovalGroup,
+			Severity: sensitiveSeverity,
+			Action:   approveAction,
+			Address:  address,
+			Summary:  "sensitive output value changed",
+		})
+	}
+	return matches
 }
 
-type RootModuleConfig struct {
-	Resources []ResourceConfig          `json:"resources"`
-	Variables map[string]VariableConfig `json:"variables"`
+// approvalGroups returns the distinct groups referenced by approve-action
+// matches, in the order each group first appeared. Deny matches don't route
+// to a group for approval; they block the run outright.
+func approvalGroups(matches []ruleMatch) []string {
+	seen := map[string]bool{}
+	var groups []string
+	for _, m := range matches {
+		if m.Action != approveAction || seen[m.Group] {
+			continue
+		}
+		seen[m.Group] = true
+		groups = append(groups, m.Group)
+	}
+	return groups
 }
 
-type ResourceConfig struct {
-	Address           string      `json:"address"`
-	Mode              string      `json:"mode"`
-	Type              string      `json:"type"`
-	Name              string      `json:"name"`
-	ProviderConfigKey string      `json:"provider_config_key"`
-	Expressions       Expressions `json:"expressions"`
-	SchemaVersion     int         `json:"schema_version"`
+// denyMatches returns the matches whose rule's action is "deny".
+func denyMatches(matches []ruleMatch) []ruleMatch {
+	var denied []ruleMatch
+	for _, m := range matches {
+		if m.Action == denyAction {
+			denied = append(denied, m)
+		}
+	}
+	return denied
 }
 
-type Expressions struct {
-	Name Expression `json:"name"`
+// primaryApprovalGroup returns the group of the highest-severity
+// approve-action match, breaking ties in favor of whichever group matched
+// first. Returns "" if there are no approve-action matches.
+func primaryApprovalGroup(matches []ruleMatch) string {
+	best := ""
+	bestSeverity := 0
+	haveBest := false
+	for _, m := range matches {
+		if m.Action != approveAction {
+			continue
+		}
+		if !haveBest || m.Severity > bestSeverity {
+			best = m.Group
+			bestSeverity = m.Severity
+			haveBest = true
+		}
+	}
+	return best
 }
 
-type VariableConfig struct {
-	Default     interface{} `json:"default"`
-	Description string      `json:"description,omitempty"`
-	Sensitive   bool        `json:"sensitive,omitempty"`
+// outputKey turns an approval group name into a GitHub Actions output key,
+// e.g. "Security" -> "approval_security".
+func outputKey(group string) string {
+	return "approval_" + strings.ToLower(strings.ReplaceAll(group, " ", "_"))
 }
 
-func main() {
-	tfPlanPath := flag.String("tfplan", "", "Path to the Terraform plan file in JSON format")
-	flag.Parse()
+// ruleNames returns every rule name the report should account for, whether
+// or not it ended up matching anything: the implicit sensitive-attribute
+// check followed by every rule in policy, in declaration order.
+func ruleNames(policy *Policy) []string {
+	names := make([]string, 0, len(policy.Rules)+1)
+	names = append(names, sensitiveRuleName)
+	for _, rule := range policy.Rules {
+		names = append(names, rule.Name)
+	}
+	return names
+}
 
-	if *tfPlanPath == "" {
-		fmt.Println("Usage: -tfplan <path to terraform plan json>")
-		return
+// actionSummary renders a change's actions as a short human-readable verb
+// phrase, e.g. "create" or "delete then create" for a replace.
+func actionSummary(change *tfjson.Change) string {
+	actions := make([]string, 0, len(change.Actions))
+	for _, action := range change.Actions {
+		actions = append(actions, string(action))
+	}
+	if len(actions) == 0 {
+		return "no-op"
 	}
+	return strings.Join(actions, " then ")
+}
 
-	planFile, err := os.ReadFile(*tfPlanPath)
+// checkFormatVersion rejects plans produced by a Terraform version whose JSON
+// plan format falls outside the range this tool understands. Without this
+// check, a Terraform upgrade that bumps format_version could silently pass
+// through unpopulated struct fields and let security-relevant changes skip
+// approval instead of failing loudly.
+func checkFormatVersion(formatVersion string) error {
+	constraints, err := version.NewConstraint(tfjson.PlanFormatVersionConstraints)
 	if err != nil {
-		fmt.Printf("Error reading plan file: %v\n", err)
-		return
+		return fmt.Errorf("parsing supported format version constraints %q: %w", tfjson.PlanFormatVersionConstraints, err)
 	}
 
-	var plan TerraformPlan
-	err = json.Unmarshal(planFile, &plan)
+	v, err := version.NewVersion(formatVersion)
 	if err != nil {
-		fmt.Printf("Error unmarshalling JSON: %v\n", err)
-		return
+		return fmt.Errorf("parsing plan format_version %q: %w", formatVersion, err)
 	}
 
-	// Define your security-related conditions here
-	securityResources := map[string]bool{
-		"jamfpro_api_integration":               true,
-		"jamfpro_disk_encryption_configuration": true,
-		// Add more resources or properties that you consider security-related
+	if !constraints.Check(v) {
+		return fmt.Errorf("plan format_version %q does not satisfy %q; regenerate the plan with a supported Terraform version or upgrade this tool", formatVersion, tfjson.PlanFormatVersionConstraints)
 	}
 
-	securityChangesDetected := false
+	return nil
+}
 
+// buildResourceContexts flattens plan.ResourceChanges and plan.ResourceDrift
+// into resourceContexts. Both already span every module instance in the
+// plan (addresses are absolute, e.g. "module.foo.jamfpro_policy.bar"), so
+// resources declared inside a reusable module are scanned the same as
+// root-module resources without any extra module-tree walking here.
+// ResourceDrift entries are marked Drifted so downstream matching can tell
+// a planned change from infrastructure that diverged outside Terraform.
+func buildResourceContexts(plan *tfjson.Plan) []*resourceContext {
+	contexts := make([]*resourceContext, 0, len(plan.ResourceChanges)+len(plan.ResourceDrift))
 	for _, change := range plan.ResourceChanges {
-		// Check if the resource type is one of the security related resources
-		if _, ok := securityResources[change.Type]; ok {
-			// Check the actions for create, update, or delete
-			for _, action := range change.Change.Actions {
-				if action == "create" || action == "update" || action == "delete" {
-					securityChangesDetected = true
-					fmt.Printf("Security-related change detected: %s action on %s\n", action, change.Address)
-					break // Break out of the inner loop once a security-related change is found
-				}
-			}
-			if securityChangesDetected {
-				break // Break out of the outer loop once a security-related change is found
-			}
-		}
+		contexts = append(contexts, &resourceContext{
+			Address:  change.Address,
+			Type:     change.Type,
+			Mode:     change.Mode,
+			Provider: change.ProviderName,
+			Change:   change.Change,
+		})
 	}
-
-	if securityChangesDetected {
-		fmt.Println("Security-related changes detected in the terraform plan. Setting the 'Security' group for the GitHub PR approval.")
-		// Set the GitHub Actions environment variable for the approval group
-		fmt.Println("::set-output name=approval_group::Security")
-	} else {
-		fmt.Println("No security-related changes detected.")
+	for _, drift := range plan.ResourceDrift {
+		contexts = append(contexts, &resourceContext{
+			Address:  drift.Address,
+			Type:     drift.Type,
+			Mode:     drift.Mode,
+			Provider: drift.ProviderName,
+			Change:   drift.Change,
+			Drifted:  true,
+		})
 	}
-}
\ No newline at end of file
+
+	return contexts
+}
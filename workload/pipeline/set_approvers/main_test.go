@@ -0,0 +1,176 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestApprovalGroups(t *testing.T) {
+	matches := []ruleMatch{
+		{Group: "Security", Action: approveAction},
+		{Group: "Compliance", Action: approveAction},
+		{Group: "Security", Action: approveAction},
+		{Group: "Platform", Action: denyAction},
+	}
+
+	got := approvalGroups(matches)
+	want := []string{"Security", "Compliance"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("approvalGroups = %v, want %v", got, want)
+	}
+}
+
+func TestDenyMatches(t *testing.T) {
+	matches := []ruleMatch{
+		{Rule: "a", Action: approveAction},
+		{Rule: "b", Action: denyAction},
+		{Rule: "c", Action: denyAction},
+	}
+
+	denied := denyMatches(matches)
+	if len(denied) != 2 {
+		t.Fatalf("denyMatches: got %d, want 2", len(denied))
+	}
+	if denied[0].Rule != "b" || denied[1].Rule != "c" {
+		t.Fatalf("denyMatches = %+v, want rules b and c in order", denied)
+	}
+}
+
+func TestPrimaryApprovalGroupHighestSeverityWins(t *testing.T) {
+	matches := []ruleMatch{
+		{Group: "Platform", Action: approveAction, Severity: 10},
+		{Group: "Security", Action: approveAction, Severity: 100},
+		{Group: "Compliance", Action: approveAction, Severity: 50},
+	}
+
+	got := primaryApprovalGroup(matches)
+	if got != "Security" {
+		t.Fatalf("primaryApprovalGroup = %q, want %q", got, "Security")
+	}
+}
+
+func TestPrimaryApprovalGroupTieBreaksOnFirstMatch(t *testing.T) {
+	matches := []ruleMatch{
+		{Group: "Compliance", Action: approveAction, Severity: 50},
+		{Group: "Platform", Action: approveAction, Severity: 50},
+	}
+
+	got := primaryApprovalGroup(matches)
+	if got != "Compliance" {
+		t.Fatalf("primaryApprovalGroup = %q, want %q (first matched at the tied severity)", got, "Compliance")
+	}
+}
+
+func TestPrimaryApprovalGroupIgnoresDenyMatches(t *testing.T) {
+	matches := []ruleMatch{
+		{Group: "Platform", Action: denyAction, Severity: 1000},
+	}
+
+	got := primaryApprovalGroup(matches)
+	if got != "" {
+		t.Fatalf("primaryApprovalGroup = %q, want \"\" when only deny matches are present", got)
+	}
+}
+
+func TestOutputKey(t *testing.T) {
+	if got := outputKey("Security"); got != "approval_security" {
+		t.Fatalf("outputKey(Security) = %q, want %q", got, "approval_security")
+	}
+	if got := outputKey("Platform Ops"); got != "approval_platform_ops" {
+		t.Fatalf("outputKey(Platform Ops) = %q, want %q", got, "approval_platform_ops")
+	}
+}
+
+func TestCheckFormatVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		formatVersion string
+		wantErr       bool
+	}{
+		{name: "in range", formatVersion: "1.2", wantErr: false},
+		{name: "too old", formatVersion: "0.0", wantErr: true},
+		{name: "too new", formatVersion: "2.0", wantErr: true},
+		{name: "unparsable", formatVersion: "not-a-version", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkFormatVersion(tt.formatVersion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkFormatVersion(%q) error = %v, wantErr %v", tt.formatVersion, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildResourceContexts(t *testing.T) {
+	plan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			{
+				Address:      `module.foo["bar"].jamfpro_policy.main`,
+				Type:         "jamfpro_policy",
+				Mode:         tfjson.ManagedResourceMode,
+				ProviderName: "registry.terraform.io/jamf/jamfpro",
+				Change:       &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionUpdate}},
+			},
+		},
+		ResourceDrift: []*tfjson.ResourceChange{
+			{
+				Address:      "jamfpro_disk_encryption_configuration.main",
+				Type:         "jamfpro_disk_encryption_configuration",
+				Mode:         tfjson.ManagedResourceMode,
+				ProviderName: "registry.terraform.io/jamf/jamfpro",
+				Change:       &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionUpdate}},
+			},
+		},
+	}
+
+	contexts := buildResourceContexts(plan)
+	if len(contexts) != 2 {
+		t.Fatalf("buildResourceContexts: got %d contexts, want 2", len(contexts))
+	}
+
+	planned := contexts[0]
+	if planned.Address != `module.foo["bar"].jamfpro_policy.main` {
+		t.Fatalf("planned.Address = %q, want the module instance address to round-trip unchanged", planned.Address)
+	}
+	if planned.Drifted {
+		t.Fatal("planned.Drifted = true, want false for a plan.ResourceChanges entry")
+	}
+
+	drifted := contexts[1]
+	if drifted.Address != "jamfpro_disk_encryption_configuration.main" {
+		t.Fatalf("drifted.Address = %q, want %q", drifted.Address, "jamfpro_disk_encryption_configuration.main")
+	}
+	if !drifted.Drifted {
+		t.Fatal("drifted.Drifted = false, want true for a plan.ResourceDrift entry")
+	}
+}
+
+func TestEvaluateOutputChangesSensitive(t *testing.T) {
+	outputChanges := map[string]*tfjson.Change{
+		"api_token": {
+			Before:          "old-token",
+			After:           "new-token",
+			BeforeSensitive: true,
+			AfterSensitive:  true,
+		},
+		"hostname": {
+			Before: "old.jamfcloud.com",
+			After:  "new.jamfcloud.com",
+		},
+	}
+
+	matches := evaluateOutputChanges(outputChanges)
+	if len(matches) != 1 {
+		t.Fatalf("evaluateOutputChanges: got %d matches, want 1", len(matches))
+	}
+	if matches[0].Address != "output.api_token" {
+		t.Fatalf("matches[0].Address = %q, want %q", matches[0].Address, "output.api_token")
+	}
+	if matches[0].Group != sensitiveApprovalGroup {
+		t.Fatalf("matches[0].Group = %q, want %q", matches[0].Group, sensitiveApprovalGroup)
+	}
+}
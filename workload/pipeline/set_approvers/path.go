@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// wildcardIndex marks a "[*]" segment produced by splitIndexes. It is never
+// added to a Path's steps directly; ParsePath strips it and reports
+// wildcard=true instead, since "any element" isn't a single traversable
+// index.
+const wildcardIndex = -1
+
+// Path is a small re-implementation of the traversal model used by
+// terraform-plugin-testing's tfjsonpath package: a chain of steps built with
+// New/AtMapKey/AtSliceIndex that walks a decoded
+// map[string]interface{}/[]interface{} tree such as change.before or
+// change.after from a Terraform JSON plan.
+type Path struct {
+	steps []pathStep
+}
+
+type pathStep struct {
+	mapKey  string
+	index   int
+	isIndex bool
+}
+
+// New starts a Path at the given top-level map key.
+func New(key string) Path {
+	return Path{steps: []pathStep{{mapKey: key}}}
+}
+
+// AtMapKey extends the path into a nested map.
+func (p Path) AtMapKey(key string) Path {
+	return p.appendStep(pathStep{mapKey: key})
+}
+
+// AtSliceIndex extends the path into a slice element.
+func (p Path) AtSliceIndex(index int) Path {
+	return p.appendStep(pathStep{index: index, isIndex: true})
+}
+
+func (p Path) appendStep(s pathStep) Path {
+	steps := make([]pathStep, len(p.steps)+1)
+	copy(steps, p.steps)
+	steps[len(p.steps)] = s
+	return Path{steps: steps}
+}
+
+// String renders the path in the same dotted/bracketed notation accepted by
+// ParsePath, e.g. "spec.disk_encryption.enabled".
+func (p Path) String() string {
+	var b strings.Builder
+	for i, s := range p.steps {
+		if s.isIndex {
+			fmt.Fprintf(&b, "[%d]", s.index)
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(s.mapKey)
+	}
+	return b.String()
+}
+
+// Traverse walks data following p and reports the value found there, or
+// ok=false if a step's key or index was absent, or a step expected a
+// map/slice and found something else.
+func Traverse(data interface{}, p Path) (value interface{}, ok bool) {
+	current := data
+	for _, s := range p.steps {
+		if s.isIndex {
+			slice, isSlice := current.([]interface{})
+			if !isSlice || s.index < 0 || s.index >= len(slice) {
+				return nil, false
+			}
+			current = slice[s.index]
+			continue
+		}
+
+		m, isMap := current.(map[string]interface{})
+		if !isMap {
+			return nil, false
+		}
+		current, ok = m[s.mapKey]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// ParsePath parses a dotted attribute-path expression such as
+// "spec.disk_encryption.enabled" or "spec.privileges[*]" into a Path. A
+// trailing "[*]" segment is reported via wildcard=true and excluded from the
+// returned Path, so callers resolve it up to the containing collection and
+// compare the collection as a whole rather than a single element.
+func ParsePath(expr string) (path Path, wildcard bool, err error) {
+	segments := strings.Split(expr, ".")
+	for i, segment := range segments {
+		key, indexes, err := splitIndexes(segment)
+		if err != nil {
+			return Path{}, false, fmt.Errorf("segment %q: %w", segment, err)
+		}
+
+		if i == 0 {
+			path = New(key)
+		} else {
+			path = path.AtMapKey(key)
+		}
+
+		for _, idx := range indexes {
+			if idx == wildcardIndex {
+				if i != len(segments)-1 {
+					return Path{}, false, fmt.Errorf("segment %q: wildcard index is only supported as the final segment", segment)
+				}
+				wildcard = true
+				continue
+			}
+			path = path.AtSliceIndex(idx)
+		}
+	}
+
+	return path, wildcard, nil
+}
+
+// splitIndexes splits a path segment like "privileges[*]" or "tags[0][1]"
+// into its map key and any trailing slice indexes.
+func splitIndexes(segment string) (key string, indexes []int, err error) {
+	key = segment
+	for {
+		open := strings.IndexByte(key, '[')
+		if open == -1 {
+			break
+		}
+		closeOffset := strings.IndexByte(key[open:], ']')
+		if closeOffset == -1 {
+			return "", nil, fmt.Errorf("unbalanced '['")
+		}
+		closeIdx := open + closeOffset
+
+		raw := key[open+1 : closeIdx]
+		if raw == "*" {
+			indexes = append(indexes, wildcardIndex)
+		} else {
+			idx, err := strconv.Atoi(raw)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid index %q", raw)
+			}
+			indexes = append(indexes, idx)
+		}
+
+		key = key[:open] + key[closeIdx+1:]
+	}
+
+	return key, indexes, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import "testing"
+
+func TestParsePathAndTraverse(t *testing.T) {
+	data := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"disk_encryption": map[string]interface{}{
+				"enabled": true,
+			},
+			"privileges": []interface{}{"a", "b"},
+			"tags":       []interface{}{[]interface{}{"x", "y"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		want     interface{}
+		wantOK   bool
+		wildcard bool
+	}{
+		{
+			name:   "nested map key",
+			expr:   "spec.disk_encryption.enabled",
+			want:   true,
+			wantOK: true,
+		},
+		{
+			name:   "slice index",
+			expr:   "spec.privileges[1]",
+			want:   "b",
+			wantOK: true,
+		},
+		{
+			name:   "nested slice index",
+			expr:   "spec.tags[0][1]",
+			want:   "y",
+			wantOK: true,
+		},
+		{
+			name:     "wildcard index",
+			expr:     "spec.privileges[*]",
+			want:     []interface{}{"a", "b"},
+			wantOK:   true,
+			wildcard: true,
+		},
+		{
+			name:   "missing map key",
+			expr:   "spec.missing",
+			wantOK: false,
+		},
+		{
+			name:   "out of range index",
+			expr:   "spec.privileges[5]",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, wildcard, err := ParsePath(tt.expr)
+			if err != nil {
+				t.Fatalf("ParsePath(%q): unexpected error: %v", tt.expr, err)
+			}
+			if wildcard != tt.wildcard {
+				t.Fatalf("ParsePath(%q): wildcard = %v, want %v", tt.expr, wildcard, tt.wildcard)
+			}
+
+			got, ok := Traverse(data, path)
+			if ok != tt.wantOK {
+				t.Fatalf("Traverse(%q): ok = %v, want %v", tt.expr, ok, tt.wantOK)
+			}
+			if !tt.wantOK {
+				return
+			}
+
+			gotSlice, gotIsSlice := got.([]interface{})
+			wantSlice, wantIsSlice := tt.want.([]interface{})
+			if gotIsSlice && wantIsSlice {
+				if len(gotSlice) != len(wantSlice) {
+					t.Fatalf("Traverse(%q) = %v, want %v", tt.expr, got, tt.want)
+				}
+				for i := range gotSlice {
+					if gotSlice[i] != wantSlice[i] {
+						t.Fatalf("Traverse(%q) = %v, want %v", tt.expr, got, tt.want)
+					}
+				}
+				return
+			}
+
+			if got != tt.want {
+				t.Fatalf("Traverse(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePathWildcardMustBeFinalSegment(t *testing.T) {
+	if _, _, err := ParsePath("spec[*].enabled"); err == nil {
+		t.Fatal("expected an error for a non-final wildcard segment, got nil")
+	}
+}
+
+func TestParsePathInvalidIndex(t *testing.T) {
+	if _, _, err := ParsePath("spec.privileges[nope]"); err == nil {
+		t.Fatal("expected an error for a non-numeric index, got nil")
+	}
+}
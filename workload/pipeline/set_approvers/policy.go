@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is the top-level document loaded from --policy. It groups a set of
+// named Rules, each of which maps a resource-change predicate to an
+// approval group (e.g. Security, Networking, Compliance).
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule describes one predicate against a resourceContext. A change matches
+// the rule when every field the rule sets matches: ResourceType, Mode,
+// Provider and Actions narrow which changes the rule applies to, and
+// Attribute, if set, additionally requires a specific before/after
+// transition on a path within the change.
+//
+// Severity ranks Group against every other matched group when resolving
+// primary_approval_group; higher wins, ties keep the first group matched.
+// RuleAction is "approve" (the default) to route the change to Group, or
+// "deny" to fail the run outright regardless of any other rule's outcome.
+type Rule struct {
+	Name         string          `yaml:"name"`
+	Group        string          `yaml:"group"`
+	Severity     int             `yaml:"severity,omitempty"`
+	RuleAction   string          `yaml:"action,omitempty"`
+	ResourceType string          `yaml:"resource_type,omitempty"`
+	Mode         string          `yaml:"mode,omitempty"`
+	Provider     string          `yaml:"provider,omitempty"`
+	Actions      []string        `yaml:"actions,omitempty"`
+	Attribute    *AttributeMatch `yaml:"attribute,omitempty"`
+}
+
+// RuleAction values. approveAction is the default when a rule omits
+// RuleAction.
+const (
+	approveAction = "approve"
+	denyAction    = "deny"
+)
+
+// AttributeMatch addresses a value inside change.before/change.after by
+// path and optionally constrains the transition it must undergo. Path uses
+// dotted map keys and bracketed slice indexes, e.g.
+// "spec.disk_encryption.enabled" or "spec.privileges[*]" for "any element".
+// AfterUnknown, if set, requires that the path's entry in
+// change.after_unknown equal it — true to catch a value becoming "known
+// after apply", false to require it stay known. When From/To are both
+// omitted, the rule matches on any difference between before and after at
+// that path, unless AfterUnknown is set, in which case the AfterUnknown
+// check alone decides. For a "[*]" path, From and To are not consulted: the
+// rule matches when the collection differs between before and after.
+type AttributeMatch struct {
+	Path         string      `yaml:"path"`
+	From         interface{} `yaml:"from,omitempty"`
+	To           interface{} `yaml:"to,omitempty"`
+	AfterUnknown *bool       `yaml:"after_unknown,omitempty"`
+}
+
+// LoadPolicy reads and parses a YAML policy document from path.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy file: %w", err)
+	}
+
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing policy file %s: %w", path, err)
+	}
+
+	for i, rule := range p.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("policy rule %d is missing a name", i)
+		}
+		if rule.Group == "" {
+			return nil, fmt.Errorf("policy rule %q is missing a group", rule.Name)
+		}
+		switch rule.RuleAction {
+		case "", approveAction, denyAction:
+		default:
+			return nil, fmt.Errorf("policy rule %q: action must be %q or %q, got %q", rule.Name, approveAction, denyAction, rule.RuleAction)
+		}
+	}
+
+	return &p, nil
+}
+
+// action normalizes RuleAction, defaulting an empty value to approveAction.
+func (r Rule) action() string {
+	if r.RuleAction == "" {
+		return approveAction
+	}
+	return r.RuleAction
+}
+
+// defaultPolicy reproduces the tool's original hardcoded rules: create,
+// update or delete of a Jamf Pro API integration or disk encryption
+// configuration routes to Security. The one difference is that any change
+// whose actions include delete on a disk encryption configuration (a
+// replace included) matches a deny rule and fails the run instead.
+func defaultPolicy() *Policy {
+	return &Policy{
+		Rules: []Rule{
+			{
+				Name:         "jamfpro-api-integration-changed",
+				Group:        "Security",
+				Severity:     100,
+				ResourceType: "jamfpro_api_integration",
+				Actions:      []string{"create", "update", "delete"},
+			},
+			{
+				Name:         "jamfpro-disk-encryption-changed",
+				Group:        "Security",
+				Severity:     100,
+				ResourceType: "jamfpro_disk_encryption_configuration",
+				Actions:      []string{"create", "update"},
+			},
+			{
+				Name:         "jamfpro-disk-encryption-deleted",
+				Group:        "Security",
+				Severity:     100,
+				RuleAction:   denyAction,
+				ResourceType: "jamfpro_disk_encryption_configuration",
+				Actions:      []string{"delete"},
+			},
+		},
+	}
+}
+
+// Matches reports whether ctx satisfies every predicate configured on r.
+func (r Rule) Matches(ctx *resourceContext) (bool, error) {
+	if r.ResourceType != "" && r.ResourceType != ctx.Type {
+		return false, nil
+	}
+
+	if r.Mode != "" && r.Mode != string(ctx.Mode) {
+		return false, nil
+	}
+
+	if r.Provider != "" {
+		ok, err := filepath.Match(r.Provider, ctx.Provider)
+		if err != nil {
+			return false, fmt.Errorf("rule %q: invalid provider glob %q: %w", r.Name, r.Provider, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	if len(r.Actions) > 0 && !actionsInclude(ctx.Change.Actions, r.Actions) {
+		return false, nil
+	}
+
+	if r.Attribute != nil {
+		matched, err := r.Attribute.matches(ctx.Change)
+		if err != nil {
+			return false, fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// actionsInclude reports whether actions satisfies any of wanted. "replace"
+// is a pseudo-action: Terraform never emits it literally, always splitting a
+// replace into a delete/create pair, so it's checked via Actions.Replace()
+// instead of a literal string comparison.
+func actionsInclude(actions tfjson.Actions, wanted []string) bool {
+	for _, w := range wanted {
+		if w == "replace" {
+			if actions.Replace() {
+				return true
+			}
+			continue
+		}
+		for _, a := range actions {
+			if string(a) == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeValue round-trips v through JSON so values decoded from different
+// sources (YAML policy rules vs. the JSON-decoded plan) compare equal when
+// they represent the same value, e.g. YAML's int(3) and JSON's float64(3).
+func normalizeValue(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return v
+	}
+	return normalized
+}
+
+// valuesEqual compares a and b after normalizeValue, so a YAML-decoded from/to
+// value compares correctly against a JSON-decoded plan value.
+func valuesEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(normalizeValue(a), normalizeValue(b))
+}
+
+// matches resolves a.Path against change.Before/change.After/change.AfterUnknown
+// and checks it against the configured transition.
+func (a AttributeMatch) matches(change *tfjson.Change) (bool, error) {
+	path, wildcard, err := ParsePath(a.Path)
+	if err != nil {
+		return false, fmt.Errorf("invalid attribute path %q: %w", a.Path, err)
+	}
+
+	if a.AfterUnknown != nil {
+		unknown, _ := Traverse(change.AfterUnknown, path)
+		if !valuesEqual(unknown, *a.AfterUnknown) {
+			return false, nil
+		}
+	}
+
+	before, beforeOK := Traverse(change.Before, path)
+	after, afterOK := Traverse(change.After, path)
+
+	if wildcard {
+		// "[*]" means "any element of the collection changed" rather than a
+		// single indexed value, so compare the collections as a whole.
+		if !beforeOK && !afterOK {
+			return false, nil
+		}
+		return !valuesEqual(before, after), nil
+	}
+
+	if a.From == nil && a.To == nil {
+		if a.AfterUnknown != nil {
+			// AfterUnknown alone is a complete predicate; no before/after
+			// transition was asked for.
+			return true, nil
+		}
+		return !valuesEqual(before, after), nil
+	}
+
+	if a.From != nil && (!beforeOK || !valuesEqual(before, a.From)) {
+		return false, nil
+	}
+	if a.To != nil && (!afterOK || !valuesEqual(after, a.To)) {
+		return false, nil
+	}
+
+	return true, nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestAttributeMatchNumericTransition(t *testing.T) {
+	// change.Before/After are decoded from JSON, so integers arrive as
+	// float64; From/To are decoded from YAML, so integers arrive as int.
+	// The match must still succeed.
+	change := &tfjson.Change{
+		Before: map[string]interface{}{"spec": map[string]interface{}{"retry_count": float64(3)}},
+		After:  map[string]interface{}{"spec": map[string]interface{}{"retry_count": float64(5)}},
+	}
+
+	attr := AttributeMatch{Path: "spec.retry_count", From: 3, To: 5}
+	matched, err := attr.matches(change)
+	if err != nil {
+		t.Fatalf("matches: unexpected error: %v", err)
+	}
+	if !matched {
+		t.Fatal("matches = false, want true for a YAML int matching a JSON float64")
+	}
+
+	attr = AttributeMatch{Path: "spec.retry_count", From: 3, To: 4}
+	matched, err = attr.matches(change)
+	if err != nil {
+		t.Fatalf("matches: unexpected error: %v", err)
+	}
+	if matched {
+		t.Fatal("matches = true, want false when To doesn't match the actual after value")
+	}
+}
+
+func TestAttributeMatchAfterUnknown(t *testing.T) {
+	unknown := true
+	change := &tfjson.Change{
+		Before:       map[string]interface{}{"spec": map[string]interface{}{"client_secret": "old"}},
+		After:        map[string]interface{}{"spec": map[string]interface{}{"client_secret": nil}},
+		AfterUnknown: map[string]interface{}{"spec": map[string]interface{}{"client_secret": true}},
+	}
+
+	attr := AttributeMatch{Path: "spec.client_secret", AfterUnknown: &unknown}
+	matched, err := attr.matches(change)
+	if err != nil {
+		t.Fatalf("matches: unexpected error: %v", err)
+	}
+	if !matched {
+		t.Fatal("matches = false, want true when after_unknown matches")
+	}
+
+	known := false
+	attr = AttributeMatch{Path: "spec.client_secret", AfterUnknown: &known}
+	matched, err = attr.matches(change)
+	if err != nil {
+		t.Fatalf("matches: unexpected error: %v", err)
+	}
+	if matched {
+		t.Fatal("matches = true, want false when after_unknown requires known but value is unknown")
+	}
+}
+
+func TestActionsIncludeReplace(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions tfjson.Actions
+		wanted  []string
+		want    bool
+	}{
+		{
+			name:    "delete then create is a replace",
+			actions: tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate},
+			wanted:  []string{"replace"},
+			want:    true,
+		},
+		{
+			name:    "create before destroy is a replace",
+			actions: tfjson.Actions{tfjson.ActionCreate, tfjson.ActionDelete},
+			wanted:  []string{"replace"},
+			want:    true,
+		},
+		{
+			name:    "plain update is not a replace",
+			actions: tfjson.Actions{tfjson.ActionUpdate},
+			wanted:  []string{"replace"},
+			want:    false,
+		},
+		{
+			name:    "literal action still matches",
+			actions: tfjson.Actions{tfjson.ActionUpdate},
+			wanted:  []string{"update"},
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := actionsInclude(tt.actions, tt.wanted); got != tt.want {
+				t.Fatalf("actionsInclude(%v, %v) = %v, want %v", tt.actions, tt.wanted, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	rule := Rule{
+		Name:         "jamfpro-disk-encryption-disabled",
+		Group:        "Security",
+		ResourceType: "jamfpro_disk_encryption_configuration",
+		Attribute: &AttributeMatch{
+			Path: "spec.disk_encryption.enabled",
+			From: true,
+			To:   false,
+		},
+	}
+
+	ctx := &resourceContext{
+		Type: "jamfpro_disk_encryption_configuration",
+		Change: &tfjson.Change{
+			Before: map[string]interface{}{"spec": map[string]interface{}{"disk_encryption": map[string]interface{}{"enabled": true}}},
+			After:  map[string]interface{}{"spec": map[string]interface{}{"disk_encryption": map[string]interface{}{"enabled": false}}},
+		},
+	}
+
+	matched, err := rule.Matches(ctx)
+	if err != nil {
+		t.Fatalf("Matches: unexpected error: %v", err)
+	}
+	if !matched {
+		t.Fatal("Matches = false, want true")
+	}
+
+	ctx.Type = "jamfpro_policy"
+	matched, err = rule.Matches(ctx)
+	if err != nil {
+		t.Fatalf("Matches: unexpected error: %v", err)
+	}
+	if matched {
+		t.Fatal("Matches = true, want false when resource_type doesn't match")
+	}
+}
+
+func writePolicyFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing policy file: %v", err)
+	}
+	return path
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := writePolicyFile(t, `
+rules:
+  - name: jamfpro-disk-encryption-disabled
+    group: Security
+    severity: 100
+    resource_type: jamfpro_disk_encryption_configuration
+    attribute:
+      path: spec.disk_encryption.enabled
+      from: true
+      to: false
+  - name: jamfpro-disk-encryption-deleted
+    group: Security
+    action: deny
+    resource_type: jamfpro_disk_encryption_configuration
+    actions: [delete]
+`)
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadPolicy: unexpected error: %v", err)
+	}
+	if len(policy.Rules) != 2 {
+		t.Fatalf("LoadPolicy: got %d rules, want 2", len(policy.Rules))
+	}
+	if policy.Rules[0].Name != "jamfpro-disk-encryption-disabled" {
+		t.Fatalf("Rules[0].Name = %q, want %q", policy.Rules[0].Name, "jamfpro-disk-encryption-disabled")
+	}
+	if policy.Rules[1].action() != denyAction {
+		t.Fatalf("Rules[1].action() = %q, want %q", policy.Rules[1].action(), denyAction)
+	}
+}
+
+func TestLoadPolicyMissingFile(t *testing.T) {
+	if _, err := LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected an error for a missing policy file, got nil")
+	}
+}
+
+func TestLoadPolicyValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{
+			name: "missing rule name",
+			contents: `
+rules:
+  - group: Security
+    resource_type: jamfpro_policy
+`,
+		},
+		{
+			name: "missing rule group",
+			contents: `
+rules:
+  - name: jamfpro-policy-changed
+    resource_type: jamfpro_policy
+`,
+		},
+		{
+			name: "invalid action",
+			contents: `
+rules:
+  - name: jamfpro-policy-changed
+    group: Security
+    action: quarantine
+    resource_type: jamfpro_policy
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writePolicyFile(t, tt.contents)
+			if _, err := LoadPolicy(path); err == nil {
+				t.Fatalf("LoadPolicy(%s): expected a validation error, got nil", tt.name)
+			}
+		})
+	}
+}
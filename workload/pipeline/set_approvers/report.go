@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// jsonReport is the shape written by --report-json, letting downstream
+// tooling (dashboards, compliance evidence storage) consume the approval
+// decision directly instead of re-parsing CI log output.
+type jsonReport struct {
+	FormatVersion    string           `json:"format_version"`
+	TerraformVersion string           `json:"terraform_version"`
+	Timestamp        string           `json:"timestamp"`
+	MatchedRules     []jsonRuleReport `json:"matched_rules"`
+	ApprovalGroups   []string         `json:"approval_groups"`
+}
+
+type jsonRuleReport struct {
+	Rule      string   `json:"rule"`
+	Group     string   `json:"group"`
+	Addresses []string `json:"addresses"`
+}
+
+// writeJSONReport groups matches by rule and writes them to path as JSON.
+func writeJSONReport(path string, plan *tfjson.Plan, matches []ruleMatch, groups []string) error {
+	report := jsonReport{
+		FormatVersion:    plan.FormatVersion,
+		TerraformVersion: plan.TerraformVersion,
+		Timestamp:        plan.Timestamp,
+		MatchedRules:     groupMatchesByRule(matches),
+		ApprovalGroups:   groups,
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling JSON report: %w", err)
+	}
+	data = append(data, '\n')
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing JSON report to %s: %w", path, err)
+	}
+	return nil
+}
+
+func groupMatchesByRule(matches []ruleMatch) []jsonRuleReport {
+	order := make([]string, 0)
+	byRule := map[string]*jsonRuleReport{}
+
+	for _, m := range matches {
+		r, ok := byRule[m.Rule]
+		if !ok {
+			r = &jsonRuleReport{Rule: m.Rule, Group: m.Group}
+			byRule[m.Rule] = r
+			order = append(order, m.Rule)
+		}
+		r.Addresses = append(r.Addresses, m.Address)
+	}
+
+	reports := make([]jsonRuleReport, 0, len(order))
+	for _, name := range order {
+		reports = append(reports, *byRule[name])
+	}
+	return reports
+}
+
+// JUnit XML types, modeled on the schema `terraform test -junit-xml`
+// consumes: <testsuites><testsuite><testcase><failure/></testcase></testsuite></testsuites>.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// writeJUnitReport emits one <testcase> per rule name: a rule with no
+// matches passes, a rule that matched one or more resource changes fails,
+// listing the matched addresses and their diff summary in the failure body.
+func writeJUnitReport(path string, ruleNames []string, matches []ruleMatch) error {
+	addressesByRule := map[string][]string{}
+	summaryByRule := map[string][]string{}
+	for _, m := range matches {
+		addressesByRule[m.Rule] = append(addressesByRule[m.Rule], m.Address)
+		summaryByRule[m.Rule] = append(summaryByRule[m.Rule], fmt.Sprintf("%s: %s", m.Address, m.Summary))
+	}
+
+	suite := junitTestSuite{Name: "set_approvers.policy", Tests: len(ruleNames)}
+	for _, name := range ruleNames {
+		tc := junitTestCase{Name: name, Classname: "set_approvers.policy"}
+		if addrs, matched := addressesByRule[name]; matched {
+			suite.Failures++
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d resource(s) require approval", len(addrs)),
+				Body:    strings.Join(summaryByRule[name], "\n") + "\n",
+			}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	doc := junitTestSuites{Suites: []junitTestSuite{suite}}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling JUnit report: %w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+	data = append(data, '\n')
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing JUnit report to %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestGroupMatchesByRule(t *testing.T) {
+	matches := []ruleMatch{
+		{Rule: "rule-a", Group: "Security", Address: "jamfpro_policy.one"},
+		{Rule: "rule-b", Group: "Compliance", Address: "jamfpro_policy.two"},
+		{Rule: "rule-a", Group: "Security", Address: "jamfpro_policy.three"},
+	}
+
+	reports := groupMatchesByRule(matches)
+	if len(reports) != 2 {
+		t.Fatalf("groupMatchesByRule: got %d reports, want 2", len(reports))
+	}
+
+	if reports[0].Rule != "rule-a" || len(reports[0].Addresses) != 2 {
+		t.Fatalf("groupMatchesByRule: first report = %+v, want rule-a with 2 addresses", reports[0])
+	}
+	if reports[1].Rule != "rule-b" || len(reports[1].Addresses) != 1 {
+		t.Fatalf("groupMatchesByRule: second report = %+v, want rule-b with 1 address", reports[1])
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	plan := &tfjson.Plan{
+		FormatVersion:    "1.2",
+		TerraformVersion: "1.7.0",
+		Timestamp:        "2024-01-01T00:00:00Z",
+	}
+	matches := []ruleMatch{
+		{Rule: "jamfpro-disk-encryption-disabled", Group: "Security", Address: "jamfpro_disk_encryption_configuration.main"},
+	}
+	groups := []string{"Security"}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := writeJSONReport(path, plan, matches, groups); err != nil {
+		t.Fatalf("writeJSONReport: unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+
+	var got jsonReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshalling report: %v", err)
+	}
+
+	if got.TerraformVersion != "1.7.0" {
+		t.Fatalf("TerraformVersion = %q, want %q", got.TerraformVersion, "1.7.0")
+	}
+	if len(got.MatchedRules) != 1 || got.MatchedRules[0].Rule != "jamfpro-disk-encryption-disabled" {
+		t.Fatalf("MatchedRules = %+v, want one entry for jamfpro-disk-encryption-disabled", got.MatchedRules)
+	}
+	if len(got.ApprovalGroups) != 1 || got.ApprovalGroups[0] != "Security" {
+		t.Fatalf("ApprovalGroups = %v, want [Security]", got.ApprovalGroups)
+	}
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	ruleNames := []string{"jamfpro-disk-encryption-disabled", "jamfpro-script-deployed"}
+	matches := []ruleMatch{
+		{Rule: "jamfpro-disk-encryption-disabled", Address: "jamfpro_disk_encryption_configuration.main", Summary: "update on jamfpro_disk_encryption_configuration"},
+	}
+
+	path := filepath.Join(t.TempDir(), "report.xml")
+	if err := writeJUnitReport(path, ruleNames, matches); err != nil {
+		t.Fatalf("writeJUnitReport: unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+
+	var got junitTestSuites
+	if err := xml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshalling report: %v", err)
+	}
+
+	if len(got.Suites) != 1 {
+		t.Fatalf("got %d suites, want 1", len(got.Suites))
+	}
+	suite := got.Suites[0]
+	if suite.Tests != 2 {
+		t.Fatalf("Tests = %d, want 2", suite.Tests)
+	}
+	if suite.Failures != 1 {
+		t.Fatalf("Failures = %d, want 1", suite.Failures)
+	}
+	if len(suite.TestCases) != 2 {
+		t.Fatalf("got %d testcases, want 2", len(suite.TestCases))
+	}
+	if suite.TestCases[0].Failure == nil {
+		t.Fatal("expected the matched rule's testcase to carry a failure")
+	}
+	if suite.TestCases[1].Failure != nil {
+		t.Fatal("expected the unmatched rule's testcase to have no failure")
+	}
+}
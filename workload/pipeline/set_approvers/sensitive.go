@@ -0,0 +1,95 @@
+package main
+
+import (
+	"reflect"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// sensitiveApprovalGroup is the group any change touching a sensitive
+// attribute is routed to, regardless of whether the resource type appears
+// in any configured policy rule. Client secrets, API tokens, encryption
+// keys and LDAP passwords must always get a Security review.
+const sensitiveApprovalGroup = "Security"
+
+// sensitiveValueChanged reports whether change touches an attribute marked
+// sensitive by the provider schema. Change.BeforeSensitive/AfterSensitive
+// are recursive interface{} trees mirroring the shape of Before/After: a
+// bare bool means the whole value is sensitive, while a map or slice means
+// only some of its elements are, walked in parallel with the value itself.
+func sensitiveValueChanged(change *tfjson.Change) bool {
+	if change == nil {
+		return false
+	}
+	return sensitiveLeafChanged(change.BeforeSensitive, change.AfterSensitive, change.Before, change.After)
+}
+
+func sensitiveLeafChanged(beforeSensitive, afterSensitive, before, after interface{}) bool {
+	switch bs := beforeSensitive.(type) {
+	case bool:
+		if bs && !reflect.DeepEqual(before, after) {
+			return true
+		}
+	case map[string]interface{}:
+		for key, childBS := range bs {
+			childAS, _ := afterSensitive.(map[string]interface{})
+			if sensitiveLeafChanged(childBS, childAS[key], mapValue(before, key), mapValue(after, key)) {
+				return true
+			}
+		}
+	case []interface{}:
+		for i, childBS := range bs {
+			if sensitiveLeafChanged(childBS, sliceValue(afterSensitive, i), sliceValue(before, i), sliceValue(after, i)) {
+				return true
+			}
+		}
+	}
+
+	// afterSensitive can mark a key/index beforeSensitive's tree doesn't
+	// cover (a value that just became sensitive), so also walk whatever
+	// wasn't already visited above.
+	switch as := afterSensitive.(type) {
+	case bool:
+		if as && !reflect.DeepEqual(before, after) {
+			return true
+		}
+	case map[string]interface{}:
+		bs, _ := beforeSensitive.(map[string]interface{})
+		for key, childAS := range as {
+			if _, covered := bs[key]; covered {
+				continue
+			}
+			if sensitiveLeafChanged(nil, childAS, mapValue(before, key), mapValue(after, key)) {
+				return true
+			}
+		}
+	case []interface{}:
+		bs, _ := beforeSensitive.([]interface{})
+		for i, childAS := range as {
+			if i < len(bs) {
+				continue
+			}
+			if sensitiveLeafChanged(nil, childAS, sliceValue(before, i), sliceValue(after, i)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func mapValue(v interface{}, key string) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return m[key]
+}
+
+func sliceValue(v interface{}, index int) interface{} {
+	s, ok := v.([]interface{})
+	if !ok || index < 0 || index >= len(s) {
+		return nil
+	}
+	return s[index]
+}
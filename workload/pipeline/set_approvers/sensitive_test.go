@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func TestSensitiveLeafChangedWholeValueSensitive(t *testing.T) {
+	changed := sensitiveLeafChanged(true, true, "old-secret", "new-secret")
+	if !changed {
+		t.Fatal("expected a sensitive leaf whose value changed to report changed=true")
+	}
+
+	unchanged := sensitiveLeafChanged(true, true, "same-secret", "same-secret")
+	if unchanged {
+		t.Fatal("expected a sensitive leaf whose value didn't change to report changed=false")
+	}
+}
+
+func TestSensitiveLeafChangedNestedMap(t *testing.T) {
+	beforeSensitive := map[string]interface{}{"password": true}
+	afterSensitive := map[string]interface{}{"password": true}
+	before := map[string]interface{}{"password": "old", "username": "alice"}
+	after := map[string]interface{}{"password": "new", "username": "alice"}
+
+	if !sensitiveLeafChanged(beforeSensitive, afterSensitive, before, after) {
+		t.Fatal("expected a changed sensitive map key to report changed=true")
+	}
+
+	after["password"] = "old"
+	if sensitiveLeafChanged(beforeSensitive, afterSensitive, before, after) {
+		t.Fatal("expected an unchanged sensitive map key to report changed=false")
+	}
+}
+
+func TestSensitiveLeafChangedNonSensitiveChangeIgnored(t *testing.T) {
+	beforeSensitive := map[string]interface{}{"password": true}
+	afterSensitive := map[string]interface{}{"password": true}
+	before := map[string]interface{}{"password": "old", "username": "alice"}
+	after := map[string]interface{}{"password": "old", "username": "bob"}
+
+	if sensitiveLeafChanged(beforeSensitive, afterSensitive, before, after) {
+		t.Fatal("expected a change to a non-sensitive key to report changed=false")
+	}
+}
+
+func TestSensitiveLeafChangedNewlySensitiveAfter(t *testing.T) {
+	// beforeSensitive doesn't cover "token" at all; afterSensitive marks it
+	// sensitive for the first time.
+	afterSensitive := map[string]interface{}{"token": true}
+	before := map[string]interface{}{"token": "old"}
+	after := map[string]interface{}{"token": "new"}
+
+	if !sensitiveLeafChanged(nil, afterSensitive, before, after) {
+		t.Fatal("expected a value newly marked sensitive in afterSensitive to report changed=true")
+	}
+}
+
+func TestSensitiveLeafChangedSlice(t *testing.T) {
+	beforeSensitive := []interface{}{true, false}
+	afterSensitive := []interface{}{true, false}
+	before := []interface{}{"secret-old", "public-old"}
+	after := []interface{}{"secret-new", "public-new"}
+
+	if !sensitiveLeafChanged(beforeSensitive, afterSensitive, before, after) {
+		t.Fatal("expected a changed sensitive slice element to report changed=true")
+	}
+
+	after = []interface{}{"secret-old", "public-new"}
+	if sensitiveLeafChanged(beforeSensitive, afterSensitive, before, after) {
+		t.Fatal("expected only a non-sensitive slice element changing to report changed=false")
+	}
+}